@@ -0,0 +1,54 @@
+package heif2png
+
+import (
+	"context"
+	"fmt"
+	"image"
+)
+
+// Decoder абстрагирует получение метаданных и декодированных тайлов HEIF, так
+// что decode() не зависит от конкретной реализации декодера.
+type Decoder interface {
+	Info(srcFile string) (HeifInfo, error)
+	DecodeTiles(srcFile string) ([]image.Image, error)
+}
+
+// Backend выбирает реализацию Decoder.
+type Backend string
+
+const (
+	// BackendExec декодирует через внешние бинарники heif2hevc + ffmpeg
+	// (исходный пайплайн).
+	BackendExec Backend = `exec`
+	// BackendNative декодирует напрямую через libheif/libde265 (cgo).
+	// Доступен только при сборке с тегом native.
+	BackendNative Backend = `native`
+)
+
+// UnsupportedBackendError возвращается для неизвестного или недоступного в
+// данной сборке Backend.
+type UnsupportedBackendError struct {
+	Backend Backend
+}
+
+func (e *UnsupportedBackendError) Error() string {
+	return fmt.Sprintf(`heif2png: unsupported backend %q`, e.Backend)
+}
+
+// newDecoder создаёт Decoder для выбранного бэкенда. Пустой Backend означает
+// автовыбор: native, если бинарник собран с тегом native, иначе exec.
+func newDecoder(ctx context.Context, backend Backend, opts Options) (Decoder, error) {
+	switch backend {
+	case BackendExec:
+		return &ExecBackend{ctx: ctx, opts: opts}, nil
+	case BackendNative:
+		return newNativeBackend(opts)
+	case ``:
+		if hasNativeBackend {
+			return newNativeBackend(opts)
+		}
+		return &ExecBackend{ctx: ctx, opts: opts}, nil
+	default:
+		return nil, &UnsupportedBackendError{Backend: backend}
+	}
+}
@@ -0,0 +1,199 @@
+package heif2png
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ExecBackend декодирует HEIF, оборачивая внешние бинарники heif2hevc и
+// ffmpeg. Это исходный пайплайн, сохранённый как запасной вариант для сборок
+// без cgo/libheif (см. NativeBackend).
+type ExecBackend struct {
+	ctx  context.Context
+	opts Options
+}
+
+func (b *ExecBackend) Info(srcFile string) (HeifInfo, error) {
+	return heifGetInfo(b.opts, srcFile)
+}
+
+func (b *ExecBackend) DecodeTiles(srcFile string) ([]image.Image, error) {
+	dstTmp, err := ioutil.TempFile(``, `heif2png-dst-*`)
+	if err != nil {
+		return nil, errors.Wrap(err, `create temp file`)
+	}
+	dstTmpName := dstTmp.Name()
+	dstTmp.Close()
+	os.Remove(dstTmpName) // heif2hevc сам создаёт файлы по маске dstTmpName+N
+
+	hevcFiles, err := heif2hevc(b.ctx, b.opts, srcFile, dstTmpName)
+	defer func() {
+		for _, f := range hevcFiles {
+			os.Remove(f)
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	tiles := make([]image.Image, len(hevcFiles))
+
+	queue := make(chan int, len(hevcFiles))
+	for i := range hevcFiles {
+		queue <- i
+	}
+	close(queue)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	for t := 0; t < b.opts.Threads; t++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range queue {
+				img, err := hevc2Image(b.ctx, b.opts, hevcFiles[i])
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					continue
+				}
+				tiles[i] = img
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return tiles, nil
+}
+
+func heifGetInfo(opts Options, srcFile string) (info HeifInfo, err error) {
+	var stdout, stderr bytes.Buffer
+
+	args := []string{`-info`}
+	if opts.Image != nil {
+		args = append(args, `-image`, strconv.Itoa(*opts.Image))
+	}
+	args = append(args, srcFile)
+
+	cmd := exec.Command(opts.Heif2hevcPath, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return info, &InfoError{Err: errors.Wrapf(err, `exec %s -info: %s`, opts.Heif2hevcPath, stderr.Bytes())}
+	}
+
+	rd := bufio.NewReader(&stdout)
+	for {
+		line, rerr := rd.ReadBytes('\n')
+
+		if pos := bytes.IndexByte(line, '='); pos != -1 {
+			name := string(bytes.TrimSpace(line[:pos]))
+			val := string(bytes.TrimSpace(line[pos+1:]))
+
+			switch name {
+			case `width`:
+				info.Width, _ = strconv.Atoi(val)
+			case `height`:
+				info.Height, _ = strconv.Atoi(val)
+			case `rotation`:
+				info.Rotation, _ = strconv.Atoi(val)
+			case `tiles`:
+				info.Tiles, _ = strconv.Atoi(val)
+			case `rows`:
+				info.Rows, _ = strconv.Atoi(val)
+			case `cols`:
+				info.Cols, _ = strconv.Atoi(val)
+			case `exif_orientation`:
+				info.ExifOrientation, _ = strconv.Atoi(val)
+			case `primary`:
+				info.Primary, _ = strconv.Atoi(val)
+			case `images`:
+				info.Images = parseIntList(val)
+			case `aux`:
+				info.Aux = parseIntList(val)
+			}
+		}
+
+		if rerr != nil {
+			break // io.EOF
+		}
+	}
+
+	return
+}
+
+// parseIntList разбирает "1,2,3" в []int{1,2,3}, пропуская нечисловые поля.
+func parseIntList(s string) []int {
+	if s == `` {
+		return nil
+	}
+
+	parts := strings.Split(s, `,`)
+	out := make([]int, 0, len(parts))
+	for _, p := range parts {
+		if v, err := strconv.Atoi(strings.TrimSpace(p)); err == nil {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
+
+func heif2hevc(ctx context.Context, opts Options, srcFile, dstFileTmp string) (dstFiles []string, err error) {
+	var args []string
+	if opts.Image != nil {
+		args = append(args, `-image`, strconv.Itoa(*opts.Image))
+	}
+	args = append(args, srcFile, dstFileTmp)
+
+	cmd := exec.CommandContext(ctx, opts.Heif2hevcPath, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, &ExtractError{Err: errors.Wrapf(err, `exec %s: %s`, opts.Heif2hevcPath, out)}
+	}
+
+	dstFiles, _ = filepath.Glob(dstFileTmp + `*`)
+	return dstFiles, nil
+}
+
+func hevc2Image(ctx context.Context, opts Options, srcFile string) (image.Image, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, opts.FFmpegPath, `-hide_banner`, `-f`, `hevc`, `-i`, srcFile, `-f`, `image2pipe`, `-vcodec`, `png`, `-`)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, &DecodeTileError{Err: errors.Wrapf(err, `exec %s: %s`, opts.FFmpegPath, stderr.Bytes())}
+	}
+
+	img, err := png.Decode(&stdout)
+	if err != nil {
+		return nil, &DecodeTileError{Err: errors.Wrapf(err, `png decode: %s`, stdout.Bytes())}
+	}
+
+	return img, nil
+}
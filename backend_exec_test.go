@@ -0,0 +1,25 @@
+package heif2png
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIntList(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []int
+	}{
+		{in: ``, want: nil},
+		{in: `1`, want: []int{1}},
+		{in: `1,2,3`, want: []int{1, 2, 3}},
+		{in: ` 1 , 2 , 3 `, want: []int{1, 2, 3}},
+		{in: `1,x,3`, want: []int{1, 3}},
+	}
+
+	for _, tt := range tests {
+		if got := parseIntList(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf(`parseIntList(%q) = %v, want %v`, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+//go:build native
+
+package heif2png
+
+/*
+#cgo pkg-config: libheif
+#include <libheif/heif.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"unsafe"
+)
+
+const hasNativeBackend = true
+
+// NativeBackend декодирует HEIF напрямую через libheif/libde265 (cgo), минуя
+// форк heif2hevc+ffmpeg и PNG round-trip через stdout. libheif уже собирает
+// тайловые (grid) изображения самостоятельно, поэтому DecodeTiles всегда
+// возвращает один уже ассемблированный кадр, а Info отражает это через
+// Tiles=1/Rows=1/Cols=1.
+//
+// Выбор конкретного изображения контейнера (Options.Image) пока не
+// реализован: Info/DecodeTiles явно возвращают
+// UnsupportedImageSelectionError вместо того, чтобы молча повторно отдавать
+// primary. HeifInfo.ExifOrientation/Aux также пока не заполняются - только
+// Images/Primary.
+type NativeBackend struct {
+	opts Options
+}
+
+func newNativeBackend(opts Options) (Decoder, error) {
+	return &NativeBackend{opts: opts}, nil
+}
+
+func (b *NativeBackend) Info(srcFile string) (HeifInfo, error) {
+	if b.opts.Image != nil {
+		return HeifInfo{}, &UnsupportedImageSelectionError{Backend: BackendNative}
+	}
+
+	ctx, handle, err := readPrimaryHandle(srcFile)
+	if err != nil {
+		return HeifInfo{}, err
+	}
+	defer C.heif_image_handle_release(handle)
+	defer C.heif_context_free(ctx)
+
+	return HeifInfo{
+		Width:   int(C.heif_image_handle_get_width(handle)),
+		Height:  int(C.heif_image_handle_get_height(handle)),
+		Tiles:   1,
+		Rows:    1,
+		Cols:    1,
+		Images:  topLevelImageIDs(ctx),
+		Primary: int(C.heif_image_handle_get_item_id(handle)),
+	}, nil
+}
+
+func (b *NativeBackend) DecodeTiles(srcFile string) ([]image.Image, error) {
+	if b.opts.Image != nil {
+		return nil, &UnsupportedImageSelectionError{Backend: BackendNative}
+	}
+
+	ctx, handle, err := readPrimaryHandle(srcFile)
+	if err != nil {
+		return nil, err
+	}
+	defer C.heif_image_handle_release(handle)
+	defer C.heif_context_free(ctx)
+
+	var img *C.struct_heif_image
+	cerr := C.heif_decode_image(handle, &img, C.heif_colorspace_RGB, C.heif_chroma_interleaved_RGBA, nil)
+	if cerr.code != C.heif_error_Ok {
+		return nil, &DecodeTileError{Err: fmt.Errorf(`libheif decode: %s`, C.GoString(cerr.message))}
+	}
+	defer C.heif_image_release(img)
+
+	var stride C.int
+	plane := C.heif_image_get_plane_readonly(img, C.heif_channel_interleaved, &stride)
+	if plane == nil {
+		return nil, &DecodeTileError{Err: fmt.Errorf(`libheif: no interleaved plane`)}
+	}
+
+	width := int(C.heif_image_handle_get_width(handle))
+	height := int(C.heif_image_handle_get_height(handle))
+
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	src := C.GoBytes(unsafe.Pointer(plane), C.int(height)*stride)
+	for y := 0; y < height; y++ {
+		copy(out.Pix[y*out.Stride:(y+1)*out.Stride], src[y*int(stride):y*int(stride)+width*4])
+	}
+
+	return []image.Image{out}, nil
+}
+
+func readPrimaryHandle(srcFile string) (*C.struct_heif_context, *C.struct_heif_image_handle, error) {
+	ctx := C.heif_context_alloc()
+
+	cSrc := C.CString(srcFile)
+	defer C.free(unsafe.Pointer(cSrc))
+
+	if cerr := C.heif_context_read_from_file(ctx, cSrc, nil); cerr.code != C.heif_error_Ok {
+		C.heif_context_free(ctx)
+		return nil, nil, &InfoError{Err: fmt.Errorf(`libheif read: %s`, C.GoString(cerr.message))}
+	}
+
+	var handle *C.struct_heif_image_handle
+	if cerr := C.heif_context_get_primary_image_handle(ctx, &handle); cerr.code != C.heif_error_Ok {
+		C.heif_context_free(ctx)
+		return nil, nil, &InfoError{Err: fmt.Errorf(`libheif primary handle: %s`, C.GoString(cerr.message))}
+	}
+
+	return ctx, handle, nil
+}
+
+// topLevelImageIDs возвращает id всех изображений контейнера (primary +
+// вложенные: бёрсты, Live Photo, depth/alpha), как это делает heif2hevc -info
+// через поле "images=" в ExecBackend.
+func topLevelImageIDs(ctx *C.struct_heif_context) []int {
+	n := int(C.heif_context_get_number_of_top_level_images(ctx))
+	if n == 0 {
+		return nil
+	}
+
+	cids := make([]C.heif_item_id, n)
+	C.heif_context_get_list_of_top_level_image_IDs(ctx, &cids[0], C.int(n))
+
+	ids := make([]int, n)
+	for i, id := range cids {
+		ids[i] = int(id)
+	}
+
+	return ids
+}
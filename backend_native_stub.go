@@ -0,0 +1,11 @@
+//go:build !native
+
+package heif2png
+
+// hasNativeBackend равен true только в сборках с тегом native (см.
+// backend_native.go), где доступны libheif/libde265 через cgo.
+const hasNativeBackend = false
+
+func newNativeBackend(opts Options) (Decoder, error) {
+	return nil, &UnsupportedBackendError{Backend: BackendNative}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atercattus/heif2png"
+)
+
+// runConvert - поведение бинарника по умолчанию: прочитать src.heif, записать
+// dst.(png|jpg). С -all пишет dst.0.png, dst.1.png, ... - по одному файлу на
+// каждое изображение контейнера (primary + вложенные: бёрсты, Live Photo,
+// depth/alpha).
+func runConvert(args []string) {
+	var argv struct {
+		help    bool
+		version bool
+
+		ffmpegPath    string
+		heif2hevcPath string
+
+		pngCompression int
+		jpegQuality    int
+
+		threads int
+		backend string
+
+		width, height int
+		fit           string
+		stripMetadata bool
+
+		image int
+		all   bool
+	}
+
+	fs := flag.NewFlagSet(`heif2png`, flag.ExitOnError)
+	fs.BoolVar(&argv.help, `h`, false, `show this help`)
+	fs.BoolVar(&argv.version, `version`, false, `show version`)
+
+	fs.StringVar(&argv.ffmpegPath, `ffmpeg`, `ffmpeg`, `path to ffmpeg binary`)
+	fs.StringVar(&argv.heif2hevcPath, `heif2hevc`, `heif2hevc`, `path to heif2hevc binary`)
+
+	fs.IntVar(&argv.pngCompression, `png-compr`, 0, `png compression (0 - default, 1 - no, 2 - best speed, 3 - best compression)`)
+	fs.IntVar(&argv.jpegQuality, `jpeg-qual`, 90, `jpeg quality (1 - worst, 100 - best, 0 - default 90)`)
+
+	fs.IntVar(&argv.threads, `threads`, 1, `thread pool size`)
+
+	fs.StringVar(&argv.backend, `backend`, ``, `decoder backend: exec, native (empty - auto, native if compiled in)`)
+
+	fs.IntVar(&argv.width, `width`, 0, `resize to width (0 - keep original)`)
+	fs.IntVar(&argv.height, `height`, 0, `resize to height (0 - keep original)`)
+	fs.StringVar(&argv.fit, `fit`, `contain`, `resize fit when both -width and -height are set: contain, cover, stretch`)
+	fs.BoolVar(&argv.stripMetadata, `strip-metadata`, false, `ignore EXIF orientation instead of applying it automatically`)
+
+	fs.IntVar(&argv.image, `image`, -1, `decode image N of the container instead of the primary one (see -all)`)
+	fs.BoolVar(&argv.all, `all`, false, `decode every image of the container to dst.N.ext instead of just the primary one`)
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s: [options] /path/to/src.heif /path/to/dst.(png|jpg)\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s serve [options]   - run as an HTTP conversion service\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if argv.version {
+		fmt.Fprint(os.Stderr, buildVersion, "\n")
+		return
+	} else if argv.help {
+		fs.Usage()
+		return
+	} else if (argv.pngCompression < 0 || argv.pngCompression > 3) || (argv.jpegQuality < 0 || argv.jpegQuality > 100) {
+		fs.Usage()
+		return
+	} else if len(fs.Args()) < 2 {
+		fs.Usage()
+		return
+	}
+
+	srcFile := fs.Arg(0)
+	dstFile := fs.Arg(1)
+
+	opts := heif2png.Options{
+		PNGCompression: argv.pngCompression,
+		JPEGQuality:    argv.jpegQuality,
+		Threads:        argv.threads,
+		FFmpegPath:     argv.ffmpegPath,
+		Heif2hevcPath:  argv.heif2hevcPath,
+		Backend:        heif2png.Backend(argv.backend),
+		Width:          argv.width,
+		Height:         argv.height,
+		Fit:            heif2png.Fit(argv.fit),
+		StripMetadata:  argv.stripMetadata,
+	}
+
+	switch strings.ToLower(filepath.Ext(dstFile)) {
+	case `.png`:
+		opts.Format = heif2png.FormatPNG
+	case `.jpg`, `.jpeg`:
+		opts.Format = heif2png.FormatJPEG
+	default:
+		fmt.Fprintln(os.Stderr, `Unsupported dst file extension`)
+		os.Exit(1)
+	}
+
+	if argv.all {
+		convertAll(srcFile, dstFile, opts)
+		return
+	}
+
+	if argv.image >= 0 {
+		opts.Image = &argv.image
+	}
+
+	if err := convertOne(srcFile, dstFile, opts); err != nil {
+		fmt.Fprintln(os.Stderr, `Convert fail:`, err)
+		os.Exit(1)
+	}
+}
+
+func convertAll(srcFile, dstFile string, opts heif2png.Options) {
+	src, err := os.Open(srcFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, `Cannot open src file`, err)
+		os.Exit(1)
+	}
+	info, err := heif2png.Info(src, opts)
+	src.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, `Cannot read info`, err)
+		os.Exit(1)
+	}
+
+	ids := info.Images
+	if len(ids) == 0 {
+		ids = []int{info.Primary}
+	}
+
+	for i, id := range ids {
+		id := id
+		imgOpts := opts
+		imgOpts.Image = &id
+
+		if err := convertOne(srcFile, indexedName(dstFile, i), imgOpts); err != nil {
+			fmt.Fprintln(os.Stderr, `Convert fail:`, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func convertOne(srcFile, dstFile string, opts heif2png.Options) error {
+	src, err := os.Open(srcFile)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dstFile)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	return heif2png.Convert(context.Background(), src, dst, opts)
+}
+
+// indexedName вставляет ".N" перед расширением: ("dst.png", 0) -> "dst.0.png".
+func indexedName(path string, i int) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	return fmt.Sprintf(`%s.%d%s`, base, i, ext)
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestIndexedName(t *testing.T) {
+	tests := []struct {
+		path string
+		i    int
+		want string
+	}{
+		{path: `dst.png`, i: 0, want: `dst.0.png`},
+		{path: `dst.png`, i: 12, want: `dst.12.png`},
+		{path: `/tmp/out.jpeg`, i: 3, want: `/tmp/out.3.jpeg`},
+		{path: `noext`, i: 1, want: `noext.1`},
+	}
+
+	for _, tt := range tests {
+		if got := indexedName(tt.path, tt.i); got != tt.want {
+			t.Errorf(`indexedName(%q, %d) = %q, want %q`, tt.path, tt.i, got, tt.want)
+		}
+	}
+}
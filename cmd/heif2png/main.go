@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+var (
+	// Build* заполняются при сборке go build -ldflags
+	BuildTime    string
+	BuildOSUname string
+	BuildCommit  string
+	buildVersion string // объединение Build* в одну строку
+)
+
+func init() {
+	buildVersion = fmt.Sprintf(`heif2png compiled at %s by %s after %s on %s`, BuildTime, runtime.Version(),
+		BuildCommit, BuildOSUname,
+	)
+}
+
+func main() {
+	// "serve" и "worker" - подкоманды со своим набором флагов, разбираем их
+	// до основного flag.Parse(), иначе им конфликтовали бы флаги друг друга.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case `serve`:
+			runServe(os.Args[2:])
+			return
+		case `worker`:
+			runWorker(os.Args[2:])
+			return
+		}
+	}
+
+	runConvert(os.Args[1:])
+}
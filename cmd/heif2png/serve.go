@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/atercattus/heif2png/server"
+	"github.com/atercattus/heif2png/workerpool"
+)
+
+// runServe запускает "heif2png serve": HTTP-сервис конвертации поверх
+// crash-isolated пула воркеров (см. workerpool.Pool).
+func runServe(args []string) {
+	var argv struct {
+		listen  string
+		workers int
+		timeout time.Duration
+		maxBody int64
+	}
+
+	fs := flag.NewFlagSet(`heif2png serve`, flag.ExitOnError)
+	fs.StringVar(&argv.listen, `listen`, `:8080`, `address to listen on`)
+	fs.IntVar(&argv.workers, `workers`, 4, `number of decoder worker subprocesses`)
+	fs.DurationVar(&argv.timeout, `timeout`, 30*time.Second, `per-request decode timeout (0 - no timeout)`)
+	fs.Int64Var(&argv.maxBody, `max-body`, 64<<20, `max request body size in bytes (/convert and /info)`)
+	fs.Parse(args)
+
+	execPath, err := os.Executable()
+	if err != nil {
+		log.Fatalln(`resolve executable path:`, err)
+	}
+
+	pool, err := workerpool.New(execPath, argv.workers, argv.timeout)
+	if err != nil {
+		log.Fatalln(`start worker pool:`, err)
+	}
+	defer pool.Close()
+
+	srv := server.New(server.Config{
+		Pool:            pool,
+		RequestTimeout:  argv.timeout,
+		MaxRequestBytes: argv.maxBody,
+	})
+
+	fmt.Fprintf(os.Stderr, "heif2png serve: listening on %s with %d workers\n", argv.listen, argv.workers)
+	log.Fatalln(http.ListenAndServe(argv.listen, srv.Handler()))
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/atercattus/heif2png/workerpool"
+)
+
+// runWorker - внутренний режим, в котором запускает дочерние процессы
+// workerpool.Pool: читает запросы на декодирование из stdin, пишет ответы в
+// stdout. Не предназначен для ручного запуска.
+func runWorker(args []string) {
+	if err := workerpool.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, `worker fail:`, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,64 @@
+package heif2png
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+
+	"github.com/disintegration/imaging"
+)
+
+func decode(ctx context.Context, src io.Reader, opts Options) (image.Image, error) {
+	srcFile, cleanupSrc, err := writeTempFile(src, `heif2png-src-*.heif`)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupSrc()
+
+	dec, err := newDecoder(ctx, opts.Backend, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := dec.Info(srcFile)
+	if err != nil {
+		return nil, err
+	}
+	if info.Tiles == 1 {
+		info.Cols = 1
+		info.Rows = 1
+	}
+
+	tiles, err := dec.DecodeTiles(srcFile)
+	if err != nil {
+		return nil, err
+	}
+
+	dstImg := image.NewRGBA(image.Rect(0, 0, info.Width, info.Height))
+
+	var point image.Point
+	for i, tile := range tiles {
+		rect := tile.Bounds()
+		x, y := i%info.Cols, i/info.Cols
+		rect.Min.X = x * rect.Max.X
+		rect.Min.Y = y * rect.Max.Y
+		rect.Max.X += rect.Min.X
+		rect.Max.Y += rect.Min.Y
+		draw.Draw(dstImg, rect, tile, point, draw.Src)
+	}
+
+	var out image.Image = dstImg
+	if info.Rotation != 0 {
+		out = imaging.Rotate(out, float64(info.Rotation), color.Alpha{})
+	}
+	if !opts.StripMetadata && info.ExifOrientation != 0 {
+		out = applyExifOrientation(out, info.ExifOrientation)
+	}
+	if opts.Width > 0 || opts.Height > 0 {
+		out = resize(out, opts)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,47 @@
+package heif2png
+
+import "fmt"
+
+// InfoError возвращается, если не удалось получить метаданные HEIF-файла.
+type InfoError struct {
+	Err error
+}
+
+func (e *InfoError) Error() string { return fmt.Sprintf(`heif2png: get info: %s`, e.Err) }
+func (e *InfoError) Unwrap() error { return e.Err }
+
+// ExtractError возвращается при сбое извлечения HEVC-тайлов из HEIF-контейнера.
+type ExtractError struct {
+	Err error
+}
+
+func (e *ExtractError) Error() string { return fmt.Sprintf(`heif2png: extract tiles: %s`, e.Err) }
+func (e *ExtractError) Unwrap() error { return e.Err }
+
+// DecodeTileError возвращается при сбое декодирования одного HEVC-тайла.
+type DecodeTileError struct {
+	Err error
+}
+
+func (e *DecodeTileError) Error() string { return fmt.Sprintf(`heif2png: decode tile: %s`, e.Err) }
+func (e *DecodeTileError) Unwrap() error { return e.Err }
+
+// UnsupportedFormatError возвращается Convert для неизвестного Options.Format.
+type UnsupportedFormatError struct {
+	Format Format
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	return fmt.Sprintf(`heif2png: unsupported format %q`, e.Format)
+}
+
+// UnsupportedImageSelectionError возвращается, если Options.Image задан для
+// бэкенда, который пока не умеет декодировать что-либо, кроме основного
+// изображения контейнера (см. NativeBackend).
+type UnsupportedImageSelectionError struct {
+	Backend Backend
+}
+
+func (e *UnsupportedImageSelectionError) Error() string {
+	return fmt.Sprintf(`heif2png: backend %q does not support selecting a specific image`, e.Backend)
+}
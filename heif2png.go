@@ -0,0 +1,141 @@
+// Package heif2png декодирует HEIF-контейнеры (в т.ч. тайловые) в image.Image
+// и кодирует результат в PNG или JPEG. Декодирование идёт через внешние
+// бинарники heif2hevc и ffmpeg (ExecBackend) либо, в сборке с тегом native,
+// через libheif/libde265 (NativeBackend). API намеренно повторяет форму стандартных пакетов image/png и
+// image/jpeg, чтобы heif2png можно было встраивать в другие сервисы так же,
+// как любой другой декодер образов.
+package heif2png
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// HeifInfo содержит метаданные, извлечённые из HEIF-контейнера.
+type HeifInfo struct {
+	Width    int
+	Height   int
+	Rotation int
+	Tiles    int
+	Rows     int
+	Cols     int
+
+	// ExifOrientation - значение тега EXIF Orientation (1-8, 0 - отсутствует).
+	// Независимо от Rotation (поворота на уровне контейнера HEIF).
+	ExifOrientation int
+
+	// Images перечисляет id всех изображений контейнера (primary + вложенные:
+	// бёрсты, Live Photo, depth/alpha). Primary - id основного изображения.
+	// Aux - id вспомогательных изображений (depth, alpha и т.п.) из Images.
+	Images  []int
+	Primary int
+	Aux     []int
+}
+
+// DecodeConfig возвращает размеры изображения без декодирования тайлов,
+// аналогично image/png.DecodeConfig.
+func DecodeConfig(src io.Reader) (image.Config, error) {
+	return DecodeConfigWithOptions(src, Options{})
+}
+
+// DecodeConfigWithOptions аналогичен DecodeConfig, но позволяет задать пути
+// до внешних бинарников через Options.
+func DecodeConfigWithOptions(src io.Reader, opts Options) (image.Config, error) {
+	info, err := Info(src, opts)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{
+		ColorModel: color.NRGBAModel,
+		Width:      info.Width,
+		Height:     info.Height,
+	}, nil
+}
+
+// Info возвращает метаданные HEIF-контейнера (размеры, поворот, раскладку
+// тайлов) без декодирования тайлов. Используется DecodeConfig и сервером
+// heif2png serve для эндпойнта /info.
+func Info(src io.Reader, opts Options) (HeifInfo, error) {
+	opts = opts.withDefaults()
+
+	srcFile, cleanup, err := writeTempFile(src, `heif2png-src-*.heif`)
+	if err != nil {
+		return HeifInfo{}, err
+	}
+	defer cleanup()
+
+	dec, err := newDecoder(context.Background(), opts.Backend, opts)
+	if err != nil {
+		return HeifInfo{}, err
+	}
+
+	return dec.Info(srcFile)
+}
+
+// Decode декодирует HEIF-контейнер в image.Image, собирая тайлы и применяя
+// поворот контейнера, аналогично image/png.Decode.
+func Decode(src io.Reader) (image.Image, error) {
+	return DecodeWithOptions(context.Background(), src, Options{})
+}
+
+// DecodeWithOptions аналогичен Decode, но принимает context.Context (для
+// отмены внешних процессов) и Options.
+func DecodeWithOptions(ctx context.Context, src io.Reader, opts Options) (image.Image, error) {
+	opts = opts.withDefaults()
+	return decode(ctx, src, opts)
+}
+
+// Convert читает HEIF из src, декодирует и кодирует результат в dst в
+// формате, заданном в opts.Format (по умолчанию - PNG).
+func Convert(ctx context.Context, src io.Reader, dst io.Writer, opts Options) error {
+	opts = opts.withDefaults()
+
+	img, err := decode(ctx, src, opts)
+	if err != nil {
+		return err
+	}
+
+	return Encode(dst, img, opts)
+}
+
+// Encode кодирует уже декодированное изображение в dst в формате,
+// заданном в opts.Format (по умолчанию - PNG). Вынесен отдельно от Convert,
+// чтобы кодировать изображения, полученные из workerpool.Pool или другого
+// источника, минуя повторный разбор HEIF.
+func Encode(dst io.Writer, img image.Image, opts Options) error {
+	opts = opts.withDefaults()
+
+	switch opts.Format {
+	case FormatPNG, ``:
+		encoder := png.Encoder{CompressionLevel: png.CompressionLevel(-opts.PNGCompression)}
+		return errors.Wrap(encoder.Encode(dst, img), `encode png`)
+	case FormatJPEG:
+		return errors.Wrap(jpeg.Encode(dst, img, &jpeg.Options{Quality: opts.JPEGQuality}), `encode jpeg`)
+	default:
+		return &UnsupportedFormatError{Format: opts.Format}
+	}
+}
+
+func writeTempFile(src io.Reader, pattern string) (path string, cleanup func(), err error) {
+	fd, err := ioutil.TempFile(``, pattern)
+	if err != nil {
+		return ``, nil, errors.Wrap(err, `create temp file`)
+	}
+	defer fd.Close()
+
+	if _, err := io.Copy(fd, src); err != nil {
+		os.Remove(fd.Name())
+		return ``, nil, errors.Wrap(err, `write temp file`)
+	}
+
+	return fd.Name(), func() { os.Remove(fd.Name()) }, nil
+}
@@ -0,0 +1,80 @@
+package heif2png
+
+// Format задаёт целевой формат кодирования результата Convert.
+type Format string
+
+const (
+	FormatPNG  Format = `png`
+	FormatJPEG Format = `jpeg`
+)
+
+// Options описывает параметры преобразования HEIF в растровое изображение.
+type Options struct {
+	// Format выбирает кодировщик результата. Пустое значение равносильно FormatPNG.
+	Format Format
+
+	// PNGCompression соответствует image/png.CompressionLevel (0 - default,
+	// 1 - no compression, 2 - best speed, 3 - best compression).
+	PNGCompression int
+	// JPEGQuality в диапазоне 0-100. 0 означает значение по умолчанию (90).
+	JPEGQuality int
+
+	// Threads задаёт размер пула воркеров, декодирующих тайлы параллельно.
+	// Используется только ExecBackend.
+	Threads int
+
+	// FFmpegPath и Heif2hevcPath переопределяют пути до внешних бинарников.
+	// Используются только ExecBackend.
+	FFmpegPath    string
+	Heif2hevcPath string
+
+	// Backend выбирает реализацию Decoder. Пустое значение - автовыбор
+	// (native, если бинарник собран с тегом native, иначе exec).
+	Backend Backend
+
+	// Image выбирает, какое изображение HEIF-контейнера декодировать (см.
+	// HeifInfo.Images/Primary/Aux) - nil означает основное изображение
+	// контейнера.
+	Image *int
+
+	// Width и Height задают итоговый размер после декодирования (0 - не
+	// менять соответствующее измерение). Применяются после сборки тайлов и
+	// поворота, перед кодированием.
+	Width, Height int
+	// Fit задаёт способ вписывания в Width x Height, если заданы оба.
+	// Пустое значение равносильно FitContain.
+	Fit Fit
+
+	// StripMetadata, если true, отключает автоматическое применение
+	// EXIF-ориентации (HeifInfo.ExifOrientation) поверх поворота контейнера.
+	StripMetadata bool
+}
+
+// Fit задаёт способ вписывания изображения в заданный прямоугольник при
+// одновременном указании Options.Width и Options.Height.
+type Fit string
+
+const (
+	// FitContain сохраняет пропорции, вписывая изображение целиком в рамку.
+	FitContain Fit = `contain`
+	// FitCover сохраняет пропорции, заполняя рамку целиком (с обрезкой).
+	FitCover Fit = `cover`
+	// FitStretch растягивает изображение точно под Width x Height.
+	FitStretch Fit = `stretch`
+)
+
+func (o Options) withDefaults() Options {
+	if o.Threads <= 0 {
+		o.Threads = 1
+	}
+	if o.FFmpegPath == `` {
+		o.FFmpegPath = `ffmpeg`
+	}
+	if o.Heif2hevcPath == `` {
+		o.Heif2hevcPath = `heif2hevc`
+	}
+	if o.JPEGQuality <= 0 {
+		o.JPEGQuality = 90
+	}
+	return o
+}
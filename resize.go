@@ -0,0 +1,75 @@
+package heif2png
+
+import (
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+// applyExifOrientation поворачивает/отражает img согласно тегу EXIF
+// Orientation (1-8), независимо от поворота на уровне контейнера HEIF,
+// который уже применён к этому моменту.
+func applyExifOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	default:
+		return img
+	}
+}
+
+// resize приводит img к Options.Width x Options.Height согласно Options.Fit.
+// Если задано только одно из измерений, второе вычисляется с сохранением
+// пропорций и Fit не учитывается.
+func resize(img image.Image, opts Options) image.Image {
+	filter := resizeFilter(img, opts.Width, opts.Height)
+
+	if opts.Width == 0 || opts.Height == 0 {
+		return imaging.Resize(img, opts.Width, opts.Height, filter)
+	}
+
+	switch opts.Fit {
+	case FitCover:
+		return imaging.Fill(img, opts.Width, opts.Height, imaging.Center, filter)
+	case FitStretch:
+		return imaging.Resize(img, opts.Width, opts.Height, filter)
+	default: // FitContain
+		return imaging.Fit(img, opts.Width, opts.Height, filter)
+	}
+}
+
+// resizeFilter выбирает Lanczos для умеренного уменьшения размера и более
+// дешёвый Box для больших соотношений, где качество Lanczos всё равно
+// теряется на этапе усреднения множества исходных пикселей в один.
+func resizeFilter(src image.Image, width, height int) imaging.ResampleFilter {
+	b := src.Bounds()
+
+	ratio := 1.0
+	if width > 0 {
+		if r := float64(b.Dx()) / float64(width); r > ratio {
+			ratio = r
+		}
+	}
+	if height > 0 {
+		if r := float64(b.Dy()) / float64(height); r > ratio {
+			ratio = r
+		}
+	}
+
+	if ratio > 3 {
+		return imaging.Box
+	}
+	return imaging.Lanczos
+}
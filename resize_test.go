@@ -0,0 +1,60 @@
+package heif2png
+
+import (
+	"image"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestResizeFilter(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		srcW, srcH    int
+		want          imaging.ResampleFilter
+	}{
+		{name: `no resize`, srcW: 100, srcH: 100, want: imaging.Lanczos},
+		{name: `mild downscale`, width: 50, srcW: 100, srcH: 100, want: imaging.Lanczos},
+		{name: `ratio at threshold`, width: 100, srcW: 300, srcH: 300, want: imaging.Lanczos},
+		{name: `ratio past threshold`, width: 100, srcW: 301, srcH: 301, want: imaging.Box},
+		{name: `tall source picks the worse ratio`, width: 100, height: 100, srcW: 100, srcH: 400, want: imaging.Box},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewNRGBA(image.Rect(0, 0, tt.srcW, tt.srcH))
+			if got := resizeFilter(src, tt.width, tt.height); got.Support != tt.want.Support {
+				t.Errorf(`resizeFilter() support = %v, want %v`, got.Support, tt.want.Support)
+			}
+		})
+	}
+}
+
+func TestApplyExifOrientation(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+
+	tests := []struct {
+		orientation int
+		wantW       int
+		wantH       int
+	}{
+		{orientation: 0, wantW: 4, wantH: 2},
+		{orientation: 1, wantW: 4, wantH: 2},
+		{orientation: 2, wantW: 4, wantH: 2},
+		{orientation: 3, wantW: 4, wantH: 2},
+		{orientation: 4, wantW: 4, wantH: 2},
+		{orientation: 5, wantW: 2, wantH: 4},
+		{orientation: 6, wantW: 2, wantH: 4},
+		{orientation: 7, wantW: 2, wantH: 4},
+		{orientation: 8, wantW: 2, wantH: 4},
+	}
+
+	for _, tt := range tests {
+		got := applyExifOrientation(src, tt.orientation)
+		b := got.Bounds()
+		if b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+			t.Errorf(`applyExifOrientation(orientation=%d) size = %dx%d, want %dx%d`, tt.orientation, b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+}
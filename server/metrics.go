@@ -0,0 +1,30 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// counter - простой потокобезопасный счётчик для /metrics.
+type counter struct {
+	v uint64
+}
+
+func (c *counter) Add(delta uint64) { atomic.AddUint64(&c.v, delta) }
+func (c *counter) Load() uint64     { return atomic.LoadUint64(&c.v) }
+
+type metrics struct {
+	conversions     counter
+	conversionsFail counter
+	infoRequests    counter
+	workerRestarts  counter
+}
+
+// writeTo пишет счётчики в Prometheus text exposition формате.
+func (m *metrics) writeTo(w io.Writer) {
+	fmt.Fprintf(w, "heif2png_conversions_total %d\n", m.conversions.Load())
+	fmt.Fprintf(w, "heif2png_conversions_failed_total %d\n", m.conversionsFail.Load())
+	fmt.Fprintf(w, "heif2png_info_requests_total %d\n", m.infoRequests.Load())
+	fmt.Fprintf(w, "heif2png_worker_restarts_total %d\n", m.workerRestarts.Load())
+}
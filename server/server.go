@@ -0,0 +1,186 @@
+// Package server реализует HTTP-обвязку вокруг workerpool.Pool: эндпойнты
+// конвертации и получения метаданных HEIF, плюс счётчики для /metrics. Это
+// то, что запускает CLI-подкоманда "heif2png serve".
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/atercattus/heif2png"
+	"github.com/atercattus/heif2png/workerpool"
+)
+
+// defaultMaxRequestBytes ограничивает тело запроса, если Config.MaxRequestBytes
+// не задан - сервис принимает тела от недоверенных клиентов (например, из
+// пайплайна загрузки фото), и ioutil.ReadAll без лимита позволяет положить
+// процесс одним запросом с неограниченным/завышенным Content-Length ещё до
+// того, как за дело возьмётся воркер.
+const defaultMaxRequestBytes = 64 << 20 // 64 MiB
+
+// Config задаёт параметры Server.
+type Config struct {
+	// Pool - уже запущенный пул воркеров, общий для всех запросов.
+	Pool *workerpool.Pool
+	// RequestTimeout ограничивает время обработки одного запроса (0 - без
+	// ограничения).
+	RequestTimeout time.Duration
+	// MaxRequestBytes ограничивает размер тела запроса для /convert и /info
+	// (0 - использовать defaultMaxRequestBytes).
+	MaxRequestBytes int64
+}
+
+func (c Config) maxRequestBytes() int64 {
+	if c.MaxRequestBytes > 0 {
+		return c.MaxRequestBytes
+	}
+	return defaultMaxRequestBytes
+}
+
+// Server обслуживает HTTP-запросы на конвертацию HEIF поверх общего пула
+// воркеров.
+type Server struct {
+	cfg     Config
+	metrics metrics
+}
+
+// New создаёт Server поверх уже запущенного cfg.Pool.
+func New(cfg Config) *Server {
+	return &Server{cfg: cfg}
+}
+
+// Handler возвращает http.Handler со всеми маршрутами сервера:
+// POST /convert?format=png|jpeg&quality=... - тело = HEIF, ответ = закодированное изображение
+// POST /info                                 - тело = HEIF, ответ = JSON heif2png.HeifInfo
+// GET  /metrics                              - счётчики в Prometheus text exposition формате
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(`/convert`, s.handleConvert)
+	mux.HandleFunc(`/info`, s.handleInfo)
+	mux.HandleFunc(`/metrics`, s.handleMetrics)
+	return mux
+}
+
+func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `method not allowed`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.maxRequestBytes())
+	heifBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeReadBodyError(w, err)
+		return
+	}
+
+	q := r.URL.Query()
+
+	opts := heif2png.Options{Format: heif2png.Format(q.Get(`format`))}
+	if v := q.Get(`quality`); v != `` {
+		if n, err := strconv.Atoi(v); err == nil {
+			opts.JPEGQuality = n
+		}
+	}
+	if v := q.Get(`width`); v != `` {
+		if n, err := strconv.Atoi(v); err == nil {
+			opts.Width = n
+		}
+	}
+	if v := q.Get(`height`); v != `` {
+		if n, err := strconv.Atoi(v); err == nil {
+			opts.Height = n
+		}
+	}
+
+	ctx := r.Context()
+	if s.cfg.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.RequestTimeout)
+		defer cancel()
+	}
+
+	img, err := s.cfg.Pool.Decode(ctx, heifBytes, opts)
+	if err != nil {
+		s.metrics.conversionsFail.Add(1)
+		if _, crashed := err.(*workerpool.DecoderCrashedError); crashed {
+			s.metrics.workerRestarts.Add(1)
+		}
+		http.Error(w, `decode: `+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set(`Content-Type`, contentType(opts.Format))
+	if err := heif2png.Encode(w, img, opts); err != nil {
+		s.metrics.conversionsFail.Add(1)
+		http.Error(w, `encode: `+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.metrics.conversions.Add(1)
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `method not allowed`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.maxRequestBytes())
+	heifBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeReadBodyError(w, err)
+		return
+	}
+
+	s.metrics.infoRequests.Add(1)
+
+	ctx := r.Context()
+	if s.cfg.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.RequestTimeout)
+		defer cancel()
+	}
+
+	info, err := s.cfg.Pool.Info(ctx, heifBytes, heif2png.Options{})
+	if err != nil {
+		if _, crashed := err.(*workerpool.DecoderCrashedError); crashed {
+			s.metrics.workerRestarts.Add(1)
+		}
+		http.Error(w, `info: `+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set(`Content-Type`, `application/json`)
+	_ = json.NewEncoder(w).Encode(info)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(`Content-Type`, `text/plain; version=0.0.4`)
+	s.metrics.writeTo(w)
+}
+
+// writeReadBodyError отвечает 413, если тело превысило Config.MaxRequestBytes
+// (http.MaxBytesReader оборачивает это в *http.MaxBytesError), иначе - 400.
+func writeReadBodyError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, `read body: `+err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, `read body: `+err.Error(), http.StatusBadRequest)
+}
+
+func contentType(format heif2png.Format) string {
+	switch format {
+	case heif2png.FormatJPEG:
+		return `image/jpeg`
+	default:
+		return `image/png`
+	}
+}
@@ -0,0 +1,29 @@
+package workerpool
+
+import "fmt"
+
+// DecoderCrashedError возвращается, если воркер-процесс упал (сегфолт,
+// паника, таймаут) во время обработки запроса. Запрос считается неудачным,
+// воркер перезапускается, остальные запросы в других воркерах не затрагиваются.
+type DecoderCrashedError struct {
+	Err error
+}
+
+func (e *DecoderCrashedError) Error() string {
+	return fmt.Sprintf(`heif2png/workerpool: decoder crashed: %s`, e.Err)
+}
+
+func (e *DecoderCrashedError) Unwrap() error { return e.Err }
+
+// RemoteError оборачивает ошибку, которую вернул воркер при обработке
+// запроса (heif2png.InfoError, ExtractError, DecodeTileError и т.п.). Воркер
+// работает в отдельном процессе, поэтому через протокол долетает только имя
+// исходного типа и текст ошибки - Kind хранит первое, чтобы вызывающий код
+// мог отличать причины сбоя через errors.As(err, new(*RemoteError)) так же,
+// как если бы декодирование выполнялось в том же процессе.
+type RemoteError struct {
+	Kind    string
+	Message string
+}
+
+func (e *RemoteError) Error() string { return e.Message }
@@ -0,0 +1,122 @@
+// Package workerpool изолирует сбои libde265/heif2hevc (известно, что они
+// падают на повреждённом входе) от родительского процесса, запуская
+// декодирование в долгоживущих дочерних процессах "heif2png worker"
+// (подкоманда; флага -worker нет) и перезапуская упавших воркеров.
+package workerpool
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sync"
+	"time"
+
+	"github.com/atercattus/heif2png"
+)
+
+// Pool управляет фиксированным набором воркер-процессов.
+type Pool struct {
+	newWorker func() (*worker, error)
+	timeout   time.Duration
+
+	mu      sync.Mutex
+	workers []*worker
+	next    int
+}
+
+// New запускает size процессов "execPath worker" и возвращает пул поверх них.
+// timeout ограничивает время ожидания ответа от воркера на один запрос (0 -
+// без ограничения).
+func New(execPath string, size int, timeout time.Duration) (*Pool, error) {
+	return newPool(func() (*worker, error) { return startWorker(execPath) }, size, timeout)
+}
+
+// newPool - общая реализация New, параметризованная фабрикой воркеров, чтобы
+// тесты могли подставлять воркеров поверх in-memory пайпов вместо настоящих
+// подпроцессов (см. pool_test.go).
+func newPool(newWorker func() (*worker, error), size int, timeout time.Duration) (*Pool, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf(`heif2png/workerpool: pool size must be positive`)
+	}
+
+	p := &Pool{newWorker: newWorker, timeout: timeout}
+	for i := 0; i < size; i++ {
+		w, err := newWorker()
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.workers = append(p.workers, w)
+	}
+
+	return p, nil
+}
+
+// Decode отправляет heifBytes одному из воркеров пула и возвращает
+// декодированное изображение. При падении воркера запрос завершается
+// *DecoderCrashedError, а сам воркер перезапускается для следующих запросов;
+// остальные воркеры запрос не затрагивает.
+func (p *Pool) Decode(ctx context.Context, heifBytes []byte, opts heif2png.Options) (image.Image, error) {
+	p.mu.Lock()
+	w := p.workers[p.next%len(p.workers)]
+	p.next++
+	p.mu.Unlock()
+
+	img, err := w.decode(ctx, p.timeout, heifBytes, opts)
+	if _, crashed := err.(*DecoderCrashedError); crashed {
+		p.replace(w)
+	}
+
+	return img, err
+}
+
+// Info отправляет heifBytes одному из воркеров пула и возвращает метаданные
+// HEIF-контейнера, не декодируя тайлы - используется /info, чтобы эти запросы
+// делили с /convert общий пул (ограничение на число одновременных heif2hevc
+// -info) и таймаут. При падении воркера запрос завершается
+// *DecoderCrashedError, а сам воркер перезапускается, как и в Decode.
+func (p *Pool) Info(ctx context.Context, heifBytes []byte, opts heif2png.Options) (heif2png.HeifInfo, error) {
+	p.mu.Lock()
+	w := p.workers[p.next%len(p.workers)]
+	p.next++
+	p.mu.Unlock()
+
+	info, err := w.info(ctx, p.timeout, heifBytes, opts)
+	if _, crashed := err.(*DecoderCrashedError); crashed {
+		p.replace(w)
+	}
+
+	return info, err
+}
+
+func (p *Pool) replace(dead *worker) {
+	nw, err := p.newWorker()
+	if err != nil {
+		// Оставляем мёртвого воркера в пуле: следующий decode() на нём снова
+		// вернёт DecoderCrashedError и попытка перезапуска повторится.
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, cur := range p.workers {
+		if cur == dead {
+			p.workers[i] = nw
+			return
+		}
+	}
+	nw.close()
+}
+
+// Close останавливает все воркеры пула.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, w := range p.workers {
+		w.close()
+	}
+	p.workers = nil
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/atercattus/heif2png"
+)
+
+// newFakePoolWorker builds a worker usable in a Pool, together with the
+// reqR/respW ends a test drives the simulated child process through.
+func newFakePoolWorker() (w *worker, reqR io.Reader, respW io.WriteCloser) {
+	w, reqR, respW, _ = newPipeWorker()
+	return w, reqR, respW
+}
+
+func TestPoolDecodeRestartsOnCrash(t *testing.T) {
+	first, reqR, _ := newFakePoolWorker()
+
+	created := 0
+	p, err := newPool(func() (*worker, error) {
+		created++
+		if created == 1 {
+			return first, nil
+		}
+		w, _, _ := newFakePoolWorker()
+		return w, nil
+	}, 1, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf(`newPool() error = %v`, err)
+	}
+	defer p.Close()
+
+	// First worker reads the request but never answers - it hangs and gets
+	// killed, so Pool.Decode should classify it as a crash and replace it.
+	go func() {
+		var req request
+		_ = readFrame(reqR, &req)
+	}()
+
+	_, err = p.Decode(context.Background(), []byte(`heif`), heif2png.Options{})
+	var crashed *DecoderCrashedError
+	if !errors.As(err, &crashed) {
+		t.Fatalf(`Decode() error = %v, want *DecoderCrashedError`, err)
+	}
+
+	if created != 2 {
+		t.Fatalf(`pool created %d workers, want 2 (one replacement after the crash)`, created)
+	}
+
+	p.mu.Lock()
+	replaced := p.workers[0]
+	p.mu.Unlock()
+	if replaced == first {
+		t.Error(`Pool did not swap the crashed worker for its replacement`)
+	}
+}
+
+func TestPoolDecodeNoRestartOnSuccess(t *testing.T) {
+	w, reqR, respW := newFakePoolWorker()
+
+	created := 0
+	p, err := newPool(func() (*worker, error) {
+		created++
+		return w, nil
+	}, 1, time.Second)
+	if err != nil {
+		t.Fatalf(`newPool() error = %v`, err)
+	}
+	defer p.Close()
+
+	go func() {
+		var req request
+		if err := readFrame(reqR, &req); err != nil {
+			return
+		}
+		_ = writeFrame(respW, response{Bounds: image.Rect(0, 0, 2, 2), Pix: []byte{1, 2, 3, 4}})
+	}()
+
+	if _, err := p.Decode(context.Background(), []byte(`heif`), heif2png.Options{}); err != nil {
+		t.Fatalf(`Decode() error = %v`, err)
+	}
+
+	if created != 1 {
+		t.Errorf(`pool created %d workers, want 1 (no restart on success)`, created)
+	}
+}
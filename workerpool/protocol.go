@@ -0,0 +1,74 @@
+package workerpool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"image"
+	"io"
+
+	"github.com/atercattus/heif2png"
+)
+
+// requestKind различает два вида запроса к воркеру: полное декодирование
+// тайлов или только чтение метаданных (см. Pool.Decode/Pool.Info).
+type requestKind uint8
+
+const (
+	requestKindDecode requestKind = iota
+	requestKindInfo
+)
+
+// request - то, что родитель отправляет воркеру: сырые байты HEIF-файла и
+// параметры декодирования.
+type request struct {
+	Kind      requestKind
+	HeifBytes []byte
+	Opts      heif2png.Options
+}
+
+// response - то, что воркер отправляет обратно: для Decode - декодированное
+// RGBA-изображение, для Info - метаданные. ErrKind/ErrMessage непусты при
+// ошибке - см. RemoteError.
+type response struct {
+	Bounds image.Rectangle
+	Pix    []byte
+
+	Info heif2png.HeifInfo
+
+	ErrKind    string
+	ErrMessage string
+}
+
+// writeFrame пишет v в w как gob-сообщение с 4-байтным префиксом длины
+// (big-endian), чтобы читатель знал, сколько байт ждать.
+func writeFrame(w io.Writer, v interface{}) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return err
+	}
+
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(buf.Len()))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readFrame читает одно length-prefixed gob-сообщение из r в v.
+func readFrame(r io.Reader, v interface{}) error {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return err
+	}
+
+	buf := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+
+	return gob.NewDecoder(bytes.NewReader(buf)).Decode(v)
+}
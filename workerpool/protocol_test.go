@@ -0,0 +1,69 @@
+package workerpool
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/atercattus/heif2png"
+)
+
+func TestWriteReadFrame(t *testing.T) {
+	req := request{
+		Kind:      requestKindInfo,
+		HeifBytes: []byte(`fake heif bytes`),
+		Opts:      heif2png.Options{Threads: 4},
+	}
+
+	var buf bytes.Buffer
+	if err := writeFrame(&buf, req); err != nil {
+		t.Fatalf(`writeFrame() error = %v`, err)
+	}
+
+	var got request
+	if err := readFrame(&buf, &got); err != nil {
+		t.Fatalf(`readFrame() error = %v`, err)
+	}
+
+	if got.Kind != req.Kind || !bytes.Equal(got.HeifBytes, req.HeifBytes) || got.Opts.Threads != req.Opts.Threads {
+		t.Errorf(`readFrame() = %+v, want %+v`, got, req)
+	}
+}
+
+func TestWriteReadFrameMultiple(t *testing.T) {
+	var buf bytes.Buffer
+
+	resps := []response{
+		{Bounds: image.Rect(0, 0, 2, 2), Pix: []byte{1, 2, 3, 4}},
+		{ErrKind: `InfoError`, ErrMessage: `boom`},
+	}
+	for _, resp := range resps {
+		if err := writeFrame(&buf, resp); err != nil {
+			t.Fatalf(`writeFrame() error = %v`, err)
+		}
+	}
+
+	for _, want := range resps {
+		var got response
+		if err := readFrame(&buf, &got); err != nil {
+			t.Fatalf(`readFrame() error = %v`, err)
+		}
+		if got.ErrKind != want.ErrKind || got.ErrMessage != want.ErrMessage || !bytes.Equal(got.Pix, want.Pix) {
+			t.Errorf(`readFrame() = %+v, want %+v`, got, want)
+		}
+	}
+}
+
+func TestReadFrameTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeFrame(&buf, response{Pix: []byte{1, 2, 3}}); err != nil {
+		t.Fatalf(`writeFrame() error = %v`, err)
+	}
+
+	truncated := bytes.NewReader(buf.Bytes()[:buf.Len()-1])
+
+	var got response
+	if err := readFrame(truncated, &got); err == nil {
+		t.Error(`readFrame() on truncated frame: want error, got nil`)
+	}
+}
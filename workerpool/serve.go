@@ -0,0 +1,82 @@
+package workerpool
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/draw"
+	"io"
+
+	"github.com/atercattus/heif2png"
+)
+
+// Serve запускает протокол воркера поверх r/w: читает запросы, декодирует
+// HEIF через heif2png и пишет ответы, пока r не отдаст io.EOF. Вызывается из
+// подкоманды "heif2png worker" - это и есть дочерний процесс, которым управляет Pool.
+func Serve(r io.Reader, w io.Writer) error {
+	for {
+		var req request
+		if err := readFrame(r, &req); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if err := writeFrame(w, handle(req)); err != nil {
+			return err
+		}
+	}
+}
+
+func handle(req request) response {
+	if req.Kind == requestKindInfo {
+		return handleInfo(req)
+	}
+	return handleDecode(req)
+}
+
+func handleDecode(req request) response {
+	img, err := heif2png.DecodeWithOptions(context.Background(), bytes.NewReader(req.HeifBytes), req.Opts)
+	if err != nil {
+		return response{ErrKind: errorKind(err), ErrMessage: err.Error()}
+	}
+
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		b := img.Bounds()
+		converted := image.NewRGBA(b)
+		draw.Draw(converted, b, img, b.Min, draw.Src)
+		rgba = converted
+	}
+
+	return response{Bounds: rgba.Rect, Pix: rgba.Pix}
+}
+
+func handleInfo(req request) response {
+	info, err := heif2png.Info(bytes.NewReader(req.HeifBytes), req.Opts)
+	if err != nil {
+		return response{ErrKind: errorKind(err), ErrMessage: err.Error()}
+	}
+
+	return response{Info: info}
+}
+
+// errorKind возвращает имя исходного типа ошибки heif2png для передачи через
+// протокол воркера - см. RemoteError.
+func errorKind(err error) string {
+	switch err.(type) {
+	case *heif2png.InfoError:
+		return `InfoError`
+	case *heif2png.ExtractError:
+		return `ExtractError`
+	case *heif2png.DecodeTileError:
+		return `DecodeTileError`
+	case *heif2png.UnsupportedFormatError:
+		return `UnsupportedFormatError`
+	case *heif2png.UnsupportedImageSelectionError:
+		return `UnsupportedImageSelectionError`
+	default:
+		return ``
+	}
+}
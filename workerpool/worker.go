@@ -0,0 +1,181 @@
+package workerpool
+
+import (
+	"bufio"
+	"context"
+	"image"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/atercattus/heif2png"
+)
+
+// process абстрагирует убиваемый/ожидаемый дочерний процесс, чтобы worker
+// можно было в тестах приводить в движение парой in-memory пайпов вместо
+// настоящего подпроцесса (см. worker_test.go).
+type process interface {
+	Kill() error
+	Wait() error
+}
+
+// cmdProcess адаптирует *exec.Cmd под process.
+type cmdProcess struct {
+	cmd *exec.Cmd
+}
+
+func (p *cmdProcess) Kill() error {
+	if p.cmd.Process == nil {
+		return nil
+	}
+	return p.cmd.Process.Kill()
+}
+
+func (p *cmdProcess) Wait() error { return p.cmd.Wait() }
+
+// worker - один долгоживущий дочерний процесс execPath -worker, общающийся с
+// родителем по length-prefixed протоколу поверх stdin/stdout.
+type worker struct {
+	proc   process
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+
+	mu sync.Mutex
+}
+
+func newWorker(stdin io.WriteCloser, stdout io.Reader, proc process) *worker {
+	return &worker{proc: proc, stdin: stdin, stdout: bufio.NewReader(stdout)}
+}
+
+func startWorker(execPath string) (*worker, error) {
+	cmd := exec.Command(execPath, `worker`)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return newWorker(stdin, stdout, &cmdProcess{cmd: cmd}), nil
+}
+
+// decode отправляет воркеру запрос на полное декодирование и ждёт ответа.
+func (w *worker) decode(ctx context.Context, timeout time.Duration, heifBytes []byte, opts heif2png.Options) (image.Image, error) {
+	resp, err := w.roundTrip(ctx, timeout, request{Kind: requestKindDecode, HeifBytes: heifBytes, Opts: opts})
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrMessage != `` {
+		return nil, &RemoteError{Kind: resp.ErrKind, Message: resp.ErrMessage}
+	}
+
+	return &image.RGBA{
+		Pix:    resp.Pix,
+		Stride: resp.Bounds.Dx() * 4,
+		Rect:   resp.Bounds,
+	}, nil
+}
+
+// info отправляет воркеру запрос на чтение метаданных (без декодирования
+// тайлов) и ждёт ответа.
+func (w *worker) info(ctx context.Context, timeout time.Duration, heifBytes []byte, opts heif2png.Options) (heif2png.HeifInfo, error) {
+	resp, err := w.roundTrip(ctx, timeout, request{Kind: requestKindInfo, HeifBytes: heifBytes, Opts: opts})
+	if err != nil {
+		return heif2png.HeifInfo{}, err
+	}
+	if resp.ErrMessage != `` {
+		return heif2png.HeifInfo{}, &RemoteError{Kind: resp.ErrKind, Message: resp.ErrMessage}
+	}
+
+	return resp.Info, nil
+}
+
+// roundTrip отправляет req воркеру и ждёт ответа. ctx - это контекст
+// вызывающего: его отмена (клиент оборвал соединение, вызывающий код решил
+// больше не ждать) не означает, что воркер завис, поэтому сама по себе она
+// не должна убивать и перезапускать совершенно здоровый процесс. Для
+// реального зависания используется отдельный hardCtx с собственным timeout,
+// независимый от ctx - только его истечение (или ошибка чтения/записи в
+// протокол) классифицируется как DecoderCrashedError.
+func (w *worker) roundTrip(ctx context.Context, timeout time.Duration, req request) (response, error) {
+	w.mu.Lock()
+
+	hardCtx, hardCancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		hardCtx, hardCancel = context.WithTimeout(context.Background(), timeout)
+	}
+
+	if err := writeFrame(w.stdin, req); err != nil {
+		hardCancel()
+		w.mu.Unlock()
+		w.kill()
+		return response{}, &DecoderCrashedError{Err: err}
+	}
+
+	respCh := make(chan response, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		var resp response
+		if err := readFrame(w.stdout, &resp); err != nil {
+			errCh <- err
+			return
+		}
+		respCh <- resp
+	}()
+
+	select {
+	case <-ctx.Done():
+		// Вызывающий ушёл раньше ответа. Воркер остаётся занят (мьютекс не
+		// освобождаем) до тех пор, пока этот запрос не завершится сам - или
+		// пока не сработает hardCtx, означающий реальное зависание - чтобы не
+		// отдать тот же stdout-поток следующему запросу до того, как текущий
+		// ответ из него вычитан.
+		go func() {
+			defer hardCancel()
+			defer w.mu.Unlock()
+			select {
+			case <-hardCtx.Done():
+				w.kill()
+			case <-respCh:
+			case <-errCh:
+			}
+		}()
+		return response{}, ctx.Err()
+	case <-hardCtx.Done():
+		hardCancel()
+		w.mu.Unlock()
+		w.kill()
+		return response{}, &DecoderCrashedError{Err: hardCtx.Err()}
+	case err := <-errCh:
+		hardCancel()
+		w.mu.Unlock()
+		w.kill()
+		return response{}, &DecoderCrashedError{Err: err}
+	case resp := <-respCh:
+		hardCancel()
+		w.mu.Unlock()
+		return resp, nil
+	}
+}
+
+// kill жёстко останавливает сбойный процесс, чтобы следующий decode() на
+// этом воркере не завис на уже мёртвом канале.
+func (w *worker) kill() {
+	_ = w.proc.Kill()
+	_ = w.proc.Wait()
+}
+
+func (w *worker) close() {
+	w.stdin.Close()
+	_ = w.proc.Wait()
+}
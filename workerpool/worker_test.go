@@ -0,0 +1,178 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/atercattus/heif2png"
+)
+
+// fakeProcess stands in for a real *exec.Cmd in tests, tracking whether the
+// worker decided to kill it.
+type fakeProcess struct {
+	mu     sync.Mutex
+	killed bool
+}
+
+func (p *fakeProcess) Kill() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.killed = true
+	return nil
+}
+
+func (p *fakeProcess) Wait() error { return nil }
+
+func (p *fakeProcess) wasKilled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.killed
+}
+
+// newPipeWorker wires a worker up to a pair of in-memory pipes and returns it
+// alongside the "child side" ends a test can use to play the worker process:
+// reqR to read requests the worker sends, respW to send back responses.
+func newPipeWorker() (w *worker, reqR io.Reader, respW io.WriteCloser, proc *fakeProcess) {
+	stdinR, stdinW := io.Pipe()
+	respR, respWr := io.Pipe()
+
+	proc = &fakeProcess{}
+	w = newWorker(stdinW, respR, proc)
+
+	return w, stdinR, respWr, proc
+}
+
+func TestWorkerDecodeSuccess(t *testing.T) {
+	w, reqR, respW, proc := newPipeWorker()
+
+	go func() {
+		var req request
+		if err := readFrame(reqR, &req); err != nil {
+			return
+		}
+		_ = writeFrame(respW, response{Bounds: image.Rect(0, 0, 2, 2), Pix: []byte{1, 2, 3, 4}})
+	}()
+
+	img, err := w.decode(context.Background(), time.Second, []byte(`heif`), heif2png.Options{})
+	if err != nil {
+		t.Fatalf(`decode() error = %v`, err)
+	}
+	if img.Bounds().Dx() != 2 || img.Bounds().Dy() != 2 {
+		t.Errorf(`decode() bounds = %v, want 2x2`, img.Bounds())
+	}
+	if proc.wasKilled() {
+		t.Error(`decode() killed the worker process on a successful round trip`)
+	}
+}
+
+func TestWorkerDecodeRemoteError(t *testing.T) {
+	w, reqR, respW, proc := newPipeWorker()
+
+	go func() {
+		var req request
+		if err := readFrame(reqR, &req); err != nil {
+			return
+		}
+		_ = writeFrame(respW, response{ErrKind: `InfoError`, ErrMessage: `boom`})
+	}()
+
+	_, err := w.decode(context.Background(), time.Second, []byte(`heif`), heif2png.Options{})
+	var remoteErr *RemoteError
+	if !errors.As(err, &remoteErr) {
+		t.Fatalf(`decode() error = %v, want *RemoteError`, err)
+	}
+	if remoteErr.Kind != `InfoError` || remoteErr.Message != `boom` {
+		t.Errorf(`decode() RemoteError = %+v, want Kind=InfoError Message=boom`, remoteErr)
+	}
+	if proc.wasKilled() {
+		t.Error(`decode() killed the worker process on a well-formed error response`)
+	}
+}
+
+func TestWorkerDecodeHangIsCrash(t *testing.T) {
+	w, reqR, _, proc := newPipeWorker()
+
+	// Child reads the request but never answers - simulates a hung decode.
+	go func() {
+		var req request
+		_ = readFrame(reqR, &req)
+	}()
+
+	_, err := w.decode(context.Background(), 20*time.Millisecond, []byte(`heif`), heif2png.Options{})
+
+	var crashed *DecoderCrashedError
+	if !errors.As(err, &crashed) {
+		t.Fatalf(`decode() error = %v, want *DecoderCrashedError`, err)
+	}
+	if !proc.wasKilled() {
+		t.Error(`decode() did not kill a worker that hung past its own timeout`)
+	}
+}
+
+func TestWorkerDecodeCrashOnEOF(t *testing.T) {
+	w, reqR, respW, proc := newPipeWorker()
+
+	go func() {
+		var req request
+		if err := readFrame(reqR, &req); err != nil {
+			return
+		}
+		respW.Close() // child exited without responding
+	}()
+
+	_, err := w.decode(context.Background(), time.Second, []byte(`heif`), heif2png.Options{})
+
+	var crashed *DecoderCrashedError
+	if !errors.As(err, &crashed) {
+		t.Fatalf(`decode() error = %v, want *DecoderCrashedError`, err)
+	}
+	if !proc.wasKilled() {
+		t.Error(`decode() did not kill a worker whose stdout closed unexpectedly`)
+	}
+}
+
+func TestWorkerDecodeCallerCancelIsNotCrash(t *testing.T) {
+	w, reqR, respW, proc := newPipeWorker()
+
+	respond := make(chan struct{})
+	go func() {
+		var req request
+		if err := readFrame(reqR, &req); err != nil {
+			return
+		}
+		<-respond // hold the real response until after the caller cancels
+		_ = writeFrame(respW, response{Bounds: image.Rect(0, 0, 1, 1), Pix: []byte{1, 2, 3, 4}})
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := w.decode(ctx, time.Second, []byte(`heif`), heif2png.Options{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf(`decode() error = %v, want context.Canceled`, err)
+	}
+
+	var crashed *DecoderCrashedError
+	if errors.As(err, &crashed) {
+		t.Error(`decode() classified caller cancellation as a crash`)
+	}
+	if proc.wasKilled() {
+		t.Error(`decode() killed the worker for a caller cancellation, before the hard timeout could fire`)
+	}
+
+	close(respond)
+
+	// roundTrip's background goroutine only releases w.mu once the delayed
+	// response has actually been read - acquiring it here blocks until that
+	// happens, so the check below isn't racing with it.
+	w.mu.Lock()
+	w.mu.Unlock()
+	if proc.wasKilled() {
+		t.Error(`decode() killed the worker even though the cancelled request eventually responded`)
+	}
+}